@@ -0,0 +1,331 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// RenderMode selects between the classic pixel grid and the Gitea-style
+// block-shape renderer.
+type RenderMode int
+
+const (
+	ModePixel RenderMode = iota
+	ModeBlocks
+)
+
+// point is a vertex in unit-square space (0,0)-(1,1).
+type point struct {
+	X, Y float64
+}
+
+// blockPolygon is a closed polygon described by unit-square vertices.
+type blockPolygon []point
+
+// blockShape is one entry in the block table: a function that returns the
+// polygons to fill for that shape, plus whether it looks symmetric enough
+// to sit in the center cell.
+type blockShape struct {
+	polygons       func() []blockPolygon
+	centerEligible bool
+}
+
+func rectPoly(x0, y0, x1, y1 float64) blockPolygon {
+	return blockPolygon{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+}
+
+func fullSquare() []blockPolygon   { return []blockPolygon{rectPoly(0, 0, 1, 1)} }
+func emptySquare() []blockPolygon  { return nil }
+func halfTopLeft() []blockPolygon  { return []blockPolygon{{{0, 0}, {1, 0}, {0, 1}}} }
+func halfTopRight() []blockPolygon { return []blockPolygon{{{0, 0}, {1, 0}, {1, 1}}} }
+func halfBotRight() []blockPolygon { return []blockPolygon{{{1, 0}, {1, 1}, {0, 1}}} }
+func halfBotLeft() []blockPolygon  { return []blockPolygon{{{0, 0}, {1, 1}, {0, 1}}} }
+func triangleCenter() []blockPolygon {
+	return []blockPolygon{{{0.5, 0}, {1, 1}, {0, 1}}}
+}
+func diamond() []blockPolygon {
+	return []blockPolygon{{{0.5, 0}, {1, 0.5}, {0.5, 1}, {0, 0.5}}}
+}
+func plus() []blockPolygon {
+	return []blockPolygon{
+		rectPoly(0.35, 0, 0.65, 1),
+		rectPoly(0, 0.35, 1, 0.65),
+	}
+}
+func hourglass() []blockPolygon {
+	return []blockPolygon{{{0, 0}, {1, 0}, {0, 1}, {1, 1}}}
+}
+func bowtieVertical() []blockPolygon {
+	return []blockPolygon{{{0, 0}, {0, 1}, {1, 0}, {1, 1}}}
+}
+
+// quarterCircleFan builds a quarter-disk fan centered at the unit square's
+// bottom-right corner (cx, cy), radius 1, arcing from (0,cy) to (cx,0)
+// through the square's interior so it actually covers that corner.
+func quarterCircleFan(cx, cy float64) []blockPolygon {
+	const steps = 12
+	poly := make(blockPolygon, 0, steps+2)
+	poly = append(poly, point{cx, cy})
+	for i := 0; i <= steps; i++ {
+		angle := math.Pi + math.Pi/2*float64(i)/steps
+		poly = append(poly, point{
+			X: cx + math.Cos(angle),
+			Y: cy + math.Sin(angle),
+		})
+	}
+	return []blockPolygon{poly}
+}
+func quarterCircleBotRight() []blockPolygon { return quarterCircleFan(1, 1) }
+func wedgeTop() []blockPolygon {
+	return []blockPolygon{{{0.2, 0}, {0.8, 0}, {0.5, 0.6}}}
+}
+func dot() []blockPolygon {
+	const steps = 16
+	poly := make(blockPolygon, 0, steps)
+	for i := 0; i < steps; i++ {
+		angle := 2 * math.Pi * float64(i) / steps
+		poly = append(poly, point{0.5 + 0.3*math.Cos(angle), 0.5 + 0.3*math.Sin(angle)})
+	}
+	return []blockPolygon{poly}
+}
+
+// rotatePolygon rotates p by deg degrees around the unit square's center.
+func rotatePolygon(p blockPolygon, deg float64) blockPolygon {
+	rad := deg * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	out := make(blockPolygon, len(p))
+	for i, v := range p {
+		x, y := v.X-0.5, v.Y-0.5
+		out[i] = point{
+			X: 0.5 + x*cos - y*sin,
+			Y: 0.5 + x*sin + y*cos,
+		}
+	}
+	return out
+}
+
+func rotated(base func() []blockPolygon, deg float64) func() []blockPolygon {
+	return func() []blockPolygon {
+		polys := base()
+		out := make([]blockPolygon, len(polys))
+		for i, p := range polys {
+			out[i] = rotatePolygon(p, deg)
+		}
+		return out
+	}
+}
+
+// blockTable holds the ~20 block shapes used by BlockIdenticon. Only shapes
+// invariant under a 90° rotation (full, empty, diamond, plus, dot) are marked
+// centerEligible, since the center cell is drawn once and never re-rotated
+// per corner — hourglass/bowtie look lopsided there despite being symmetric
+// about one axis.
+var blockTable = []blockShape{
+	{fullSquare, true},
+	{emptySquare, true},
+	{diamond, true},
+	{plus, true},
+	{dot, true},
+	{hourglass, false},
+	{bowtieVertical, false},
+	{halfTopLeft, false},
+	{halfTopRight, false},
+	{halfBotRight, false},
+	{halfBotLeft, false},
+	{triangleCenter, false},
+	{rotated(triangleCenter, 90), false},
+	{rotated(triangleCenter, 180), false},
+	{rotated(triangleCenter, 270), false},
+	{quarterCircleBotRight, false},
+	{rotated(quarterCircleBotRight, 90), false},
+	{rotated(quarterCircleBotRight, 180), false},
+	{rotated(quarterCircleBotRight, 270), false},
+	{wedgeTop, false},
+	{rotated(wedgeTop, 90), false},
+}
+
+func centerEligibleIndices() []int {
+	var out []int
+	for i, b := range blockTable {
+		if b.centerEligible {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// fillPolygon rasterizes a polygon (in device pixel coordinates) into img
+// with a scanline fill, honoring the same bounds clipping as drawRect. Each
+// row intersects every non-horizontal edge at the pixel center and fills
+// between sorted pairs of crossings (even-odd rule); no edge table or active
+// edge list is kept, so every edge is tested on every row.
+func fillPolygon(img *image.RGBA, poly []point, c color.Color) {
+	if len(poly) < 3 {
+		return
+	}
+
+	bounds := img.Bounds()
+	minY, maxY := poly[0].Y, poly[0].Y
+	for _, p := range poly {
+		minY = math.Min(minY, p.Y)
+		maxY = math.Max(maxY, p.Y)
+	}
+
+	y0 := max(int(math.Floor(minY)), bounds.Min.Y)
+	y1 := min(int(math.Ceil(maxY)), bounds.Max.Y)
+
+	r, g, b, a := c.RGBA()
+	rgba := color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
+
+	n := len(poly)
+	for y := y0; y < y1; y++ {
+		scanY := float64(y) + 0.5
+		var xs []float64
+		for i := 0; i < n; i++ {
+			p0, p1 := poly[i], poly[(i+1)%n]
+			if p0.Y == p1.Y {
+				continue
+			}
+			if (scanY >= p0.Y && scanY < p1.Y) || (scanY >= p1.Y && scanY < p0.Y) {
+				t := (scanY - p0.Y) / (p1.Y - p0.Y)
+				xs = append(xs, p0.X+t*(p1.X-p0.X))
+			}
+		}
+		sort.Float64s(xs)
+
+		for i := 0; i+1 < len(xs); i += 2 {
+			x0 := max(int(math.Round(xs[i])), bounds.Min.X)
+			x1 := min(int(math.Round(xs[i+1])), bounds.Max.X)
+			if x0 >= x1 {
+				continue
+			}
+			rowStart := img.PixOffset(x0, y)
+			for x := 0; x < x1-x0; x++ {
+				idx := rowStart + x*4
+				img.Pix[idx] = rgba.R
+				img.Pix[idx+1] = rgba.G
+				img.Pix[idx+2] = rgba.B
+				img.Pix[idx+3] = rgba.A
+			}
+		}
+	}
+}
+
+// blockDevicePolygons returns one block-table entry's polygons, rotated by
+// deg degrees, placed into the cell [x0,y0]-[x1,y1] in device coordinates.
+func blockDevicePolygons(shapeIdx int, deg float64, x0, y0, x1, y1 int) []blockPolygon {
+	shape := blockTable[shapeIdx%len(blockTable)]
+	polys := rotated(shape.polygons, deg)()
+	out := make([]blockPolygon, len(polys))
+	for i, poly := range polys {
+		device := make(blockPolygon, len(poly))
+		for j, v := range poly {
+			device[j] = point{
+				X: float64(x0) + v.X*float64(x1-x0),
+				Y: float64(y0) + v.Y*float64(y1-y0),
+			}
+		}
+		out[i] = device
+	}
+	return out
+}
+
+// drawBlock rasterizes one block-table entry, rotated by deg degrees, into
+// the cell [x0,y0]-[x1,y1].
+func drawBlock(img *image.RGBA, shapeIdx int, deg float64, x0, y0, x1, y1 int, c color.Color) {
+	for _, poly := range blockDevicePolygons(shapeIdx, deg, x0, y0, x1, y1) {
+		fillPolygon(img, poly, c)
+	}
+}
+
+// generateBlockPattern picks a center block and one corner block+rotation
+// from the source bits, then mirrors the corner block around the other
+// three corners (rotated by +90/+180/+270) for 4-fold rotational symmetry.
+func (identicon *ClassicIdenticon) generateBlockPattern() (centerIdx int, cornerIdx int, cornerRot float64) {
+	eligible := centerEligibleIndices()
+
+	centerBits := 0
+	for i := 0; i < 4; i++ {
+		if identicon.getBit(i) {
+			centerBits |= 1 << i
+		}
+	}
+	centerIdx = eligible[centerBits%len(eligible)]
+
+	cornerBits := 0
+	for i := 0; i < 5; i++ {
+		if identicon.getBit(4 + i) {
+			cornerBits |= 1 << i
+		}
+	}
+	cornerIdx = cornerBits % len(blockTable)
+
+	rotBits := 0
+	for i := 0; i < 2; i++ {
+		if identicon.getBit(9 + i) {
+			rotBits |= 1 << i
+		}
+	}
+	cornerRot = float64(rotBits%4) * 90
+
+	return centerIdx, cornerIdx, cornerRot
+}
+
+// blockPlacement is one cell of the 3x3 block layout: which block-table
+// entry, at which rotation, goes into which device-pixel cell.
+type blockPlacement struct {
+	shapeIdx int
+	deg      float64
+	x0, y0   int
+	x1, y1   int
+}
+
+// blockCellPlacements computes the corner+center placements for this
+// identicon's source bits. The four edge cells are left as background.
+func (identicon *ClassicIdenticon) blockCellPlacements() []blockPlacement {
+	size := identicon.size
+	cell := size / 3
+	centerIdx, cornerIdx, cornerRot := identicon.generateBlockPattern()
+
+	corners := [4][2]int{
+		{0, 0},
+		{2 * cell, 0},
+		{2 * cell, 2 * cell},
+		{0, 2 * cell},
+	}
+
+	placements := make([]blockPlacement, 0, 5)
+	for i, corner := range corners {
+		x0, y0 := corner[0], corner[1]
+		placements = append(placements, blockPlacement{
+			cornerIdx, cornerRot + float64(i)*90, x0, y0, x0 + cell, y0 + cell,
+		})
+	}
+
+	placements = append(placements, blockPlacement{
+		centerIdx, 0, cell, cell, 2 * cell, 2 * cell,
+	})
+
+	return placements
+}
+
+// drawBlocks renders the 3x3 block-shape identicon (corners + center; the
+// four edge cells are left as background) into img.
+func (identicon *ClassicIdenticon) drawBlocks(img *image.RGBA, primaryColor color.Color) {
+	for _, p := range identicon.blockCellPlacements() {
+		drawBlock(img, p.shapeIdx, p.deg, p.x0, p.y0, p.x1, p.y1, primaryColor)
+	}
+}
+
+// blockPolygonsDevice returns every filled polygon of the block-shape
+// identicon in device-pixel coordinates, for renderers (SVG) that need the
+// raw shapes instead of a rasterized image.
+func (identicon *ClassicIdenticon) blockPolygonsDevice() []blockPolygon {
+	var out []blockPolygon
+	for _, p := range identicon.blockCellPlacements() {
+		out = append(out, blockDevicePolygons(p.shapeIdx, p.deg, p.x0, p.y0, p.x1, p.y1)...)
+	}
+	return out
+}
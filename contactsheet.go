@@ -0,0 +1,95 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// captionHeight is the strip reserved below each cell for its label when
+// captions are enabled.
+const captionHeight = 14
+
+// RenderContactSheet lays out the identicons for sources into a single grid
+// image with cols columns and cell x cell sub-images, for visually auditing
+// how distinguishable a batch of identicons is.
+func RenderContactSheet(sources [][]byte, cols int, cell int) image.Image {
+	return RenderContactSheetWithLabels(sources, nil, cols, cell)
+}
+
+// RenderContactSheetWithLabels is RenderContactSheet plus an optional
+// per-cell caption (rendered with basicfont) below each identicon. labels
+// must either be empty or have the same length as sources.
+func RenderContactSheetWithLabels(sources [][]byte, labels []string, cols int, cell int) image.Image {
+	if cols < 1 {
+		cols = 1
+	}
+	if cell < 1 {
+		cell = 64
+	}
+
+	rows := (len(sources) + cols - 1) / cols
+	if rows < 1 {
+		rows = 1
+	}
+
+	hasCaptions := len(labels) == len(sources) && len(labels) > 0
+
+	cellHeight := cell
+	if hasCaptions {
+		cellHeight = cell + captionHeight
+	}
+
+	sheet := image.NewRGBA(image.Rect(0, 0, cols*cell, rows*cellHeight))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	for i, src := range sources {
+		row := i / cols
+		col := i % cols
+
+		sub := NewClassicIdenticon(src).WithSize(cell).GenerateForExport(false)
+
+		x0, y0 := col*cell, row*cellHeight
+		dstRect := image.Rect(x0, y0, x0+cell, y0+cell)
+		draw.Draw(sheet, dstRect, sub, image.Point{}, draw.Over)
+
+		if hasCaptions {
+			drawCaption(sheet, labels[i], x0, y0+cell, cell)
+		}
+	}
+
+	return sheet
+}
+
+// drawCaption renders label (truncated to roughly fit width) below the cell
+// starting at (x0, y0).
+func drawCaption(img *image.RGBA, label string, x0, y0, width int) {
+	label = truncateLabel(label, width)
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(color.Black),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x0+2, y0+11),
+	}
+	d.DrawString(label)
+}
+
+// truncateLabel shortens label so basicfont.Face7x13 (7px advance) roughly
+// fits it within width pixels.
+func truncateLabel(label string, width int) string {
+	maxChars := width / 7
+	if maxChars < 1 {
+		maxChars = 1
+	}
+	if len(label) <= maxChars {
+		return label
+	}
+	if maxChars == 1 {
+		return label[:1]
+	}
+	return label[:maxChars-1] + "…"
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showContactSheetWindow opens a screen where the user can paste (or load
+// from a file) a list of labels, one per line, and render+save a contact
+// sheet of the resulting identicons.
+func showContactSheetWindow(myApp fyne.App) {
+	win := myApp.NewWindow("Contact Sheet")
+	win.Resize(fyne.NewSize(700, 520))
+
+	input := widget.NewMultiLineEntry()
+	input.SetPlaceHolder("One label per line…")
+	input.Wrapping = fyne.TextWrapOff
+
+	colsEntry := widget.NewEntry()
+	colsEntry.SetText("8")
+
+	preview := canvas.NewImageFromImage(nil)
+	preview.FillMode = canvas.ImageFillContain
+	preview.SetMinSize(fyne.NewSize(640, 360))
+
+	var sheet image.Image
+
+	openFileBtn := widget.NewButton("Load From File", func() {
+		fileDialog := dialog.NewFileOpen(func(uc fyne.URIReadCloser, err error) {
+			if err != nil || uc == nil {
+				return
+			}
+			defer uc.Close()
+
+			data, err := io.ReadAll(uc)
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			input.SetText(string(data))
+		}, win)
+		fileDialog.Show()
+	})
+
+	generateBtn := widget.NewButton("Generate Sheet", func() {
+		var sources [][]byte
+		var labels []string
+		for _, line := range strings.Split(input.Text, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			hash := sha256.Sum256([]byte(line))
+			sources = append(sources, hash[:])
+			labels = append(labels, line)
+		}
+		if len(sources) == 0 {
+			dialog.ShowInformation("Error", "Enter at least one label.", win)
+			return
+		}
+
+		cols, err := strconv.Atoi(colsEntry.Text)
+		if err != nil || cols < 1 {
+			cols = 8
+		}
+
+		sheet = RenderContactSheetWithLabels(sources, labels, cols, 96)
+		preview.Image = sheet
+		preview.Refresh()
+	})
+
+	saveBtn := widget.NewButton("Save Sheet", func() {
+		if sheet == nil {
+			dialog.ShowInformation("Error", "Generate a sheet first.", win)
+			return
+		}
+
+		fileDialog := dialog.NewFileSave(func(uc fyne.URIWriteCloser, err error) {
+			if err != nil || uc == nil {
+				return
+			}
+			defer uc.Close()
+
+			if err := png.Encode(uc, sheet); err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			dialog.ShowInformation("Success", "Contact sheet saved!", win)
+		}, win)
+		fileDialog.SetFileName("contact-sheet.png")
+		fileDialog.Show()
+	})
+
+	controls := container.NewHBox(
+		widget.NewLabel("Columns:"),
+		colsEntry,
+		openFileBtn,
+		generateBtn,
+		saveBtn,
+	)
+
+	content := container.NewBorder(
+		container.NewVBox(controls, input),
+		nil, nil, nil,
+		container.NewCenter(preview),
+	)
+
+	win.SetContent(content)
+	win.Show()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/bmp"
+)
+
+// encodeIdenticon renders identicon in the requested format ("PNG", "SVG",
+// "JPEG", "GIF", or "BMP") and returns the encoded bytes, honoring
+// transparent where the format supports it.
+func encodeIdenticon(identicon *ClassicIdenticon, format string, transparent bool) ([]byte, error) {
+	if strings.EqualFold(format, "SVG") {
+		return identicon.GenerateSVG(transparent), nil
+	}
+
+	img := identicon.GenerateForExport(transparent)
+
+	switch strings.ToUpper(format) {
+	case "PNG":
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "JPEG":
+		return EncodeJPEG(img, 90)
+	case "GIF":
+		return EncodeGIF(img)
+	case "BMP":
+		return EncodeBMP(img)
+	default:
+		return nil, fmt.Errorf("identicons: unsupported export format %q", format)
+	}
+}
+
+// GenerateSVG renders the identicon as a self-contained, resolution-
+// independent SVG built directly from the bit pattern (it does not go
+// through image/draw), so it stays crisp at any output size.
+func (identicon *ClassicIdenticon) GenerateSVG(transparent bool) []byte {
+	spriteSize := identicon.grid
+	pixelSize := identicon.size / spriteSize
+	margin := (identicon.size - pixelSize*spriteSize) / 2
+
+	size := identicon.size
+	bg := identicon.exportBackground(transparent)
+
+	primaryColor := identicon.foreground()
+	secondaryColor := identicon.secondaryColor()
+	if !transparent {
+		primaryColor = ensureContrast(primaryColor, bg, identicon.minContrast)
+		secondaryColor = ensureContrast(secondaryColor, bg, identicon.minContrast)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		size, size, size, size)
+
+	if !transparent {
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, size, size, hexColor(bg))
+	}
+
+	if identicon.mode == ModeBlocks {
+		for _, poly := range identicon.blockPolygonsDevice() {
+			writeSVGPolygon(&b, poly, primaryColor)
+		}
+	} else {
+		primaryPixels, secondaryPixels := identicon.generatePixelPattern()
+		for row := 0; row < spriteSize; row++ {
+			for col := 0; col < spriteSize; col++ {
+				if secondaryPixels[row*spriteSize+col] {
+					writeSVGRect(&b, col*pixelSize+margin, row*pixelSize+margin, pixelSize, pixelSize, secondaryColor)
+				}
+			}
+		}
+		for row := 0; row < spriteSize; row++ {
+			for col := 0; col < spriteSize; col++ {
+				if primaryPixels[row*spriteSize+col] {
+					writeSVGRect(&b, col*pixelSize+margin, row*pixelSize+margin, pixelSize, pixelSize, primaryColor)
+				}
+			}
+		}
+	}
+
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+func writeSVGRect(b *strings.Builder, x, y, w, h int, c color.Color) {
+	fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, x, y, w, h, hexColor(c))
+}
+
+func writeSVGPolygon(b *strings.Builder, poly blockPolygon, c color.Color) {
+	if len(poly) == 0 {
+		return
+	}
+	b.WriteString(`<polygon points="`)
+	for i, p := range poly {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(b, "%.2f,%.2f", p.X, p.Y)
+	}
+	fmt.Fprintf(b, `" fill="%s"/>`, hexColor(c))
+}
+
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// EncodeJPEG encodes img as JPEG at the given quality (0-100).
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeGIF encodes img as a single-frame GIF.
+func EncodeGIF(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeBMP encodes img as BMP.
+func EncodeBMP(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := bmp.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
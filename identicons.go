@@ -2,10 +2,11 @@ package main
 
 import (
 	"crypto/sha256"
+	"flag"
 	"image"
 	"image/color"
-	"image/png"
-	"math"
+	"log"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -19,18 +20,76 @@ import (
 
 // ClassicIdenticon with 100% deterministic, bit-perfect design + 2-color mode
 type ClassicIdenticon struct {
-	source []byte
-	size   int
+	source      []byte
+	size        int
+	mode        RenderMode
+	grid        int
+	symmetry    SymmetryMode
+	palette     Palette
+	minContrast float64
 }
 
+// defaultMinContrast is the WCAG AA contrast ratio for normal text (4.5:1).
+const defaultMinContrast = 4.5
+
 // NewClassicIdenticon creates a generator with classic look
 func NewClassicIdenticon(source []byte) *ClassicIdenticon {
 	return &ClassicIdenticon{
-		source: source,
-		size:   256,
+		source:      source,
+		size:        256,
+		mode:        ModePixel,
+		grid:        defaultGrid,
+		symmetry:    SymmetryHorizontal,
+		palette:     classicPalette{},
+		minContrast: defaultMinContrast,
 	}
 }
 
+// WithMode selects the render mode (pixel grid or Gitea-style blocks) and
+// returns the identicon for chaining.
+func (identicon *ClassicIdenticon) WithMode(mode RenderMode) *ClassicIdenticon {
+	identicon.mode = mode
+	return identicon
+}
+
+// WithSize sets the output size in pixels (the identicon is always square).
+func (identicon *ClassicIdenticon) WithSize(px int) *ClassicIdenticon {
+	if px > 0 {
+		identicon.size = px
+	}
+	return identicon
+}
+
+// WithGrid sets the pixel-grid resolution (5x5 .. 8x8, clamped) used by
+// ModePixel. Re-hashes the source if it doesn't carry enough bits for the
+// requested grid.
+func (identicon *ClassicIdenticon) WithGrid(n int) *ClassicIdenticon {
+	identicon.grid = clampGrid(n)
+	identicon.ensureSourceBits(2 * requiredPatternBits(identicon.grid))
+	return identicon
+}
+
+// WithSymmetry sets how the pixel grid mirrors/rotates its bits.
+func (identicon *ClassicIdenticon) WithSymmetry(symmetry SymmetryMode) *ClassicIdenticon {
+	identicon.symmetry = symmetry
+	return identicon
+}
+
+// WithPalette sets the color palette used to derive foreground, secondary,
+// and background colors from the source bits.
+func (identicon *ClassicIdenticon) WithPalette(palette Palette) *ClassicIdenticon {
+	identicon.palette = palette
+	return identicon
+}
+
+// WithMinContrast sets the minimum WCAG contrast ratio (default 4.5,
+// matching AA for normal text) enforced between the foreground/secondary
+// colors and the background.
+func (identicon *ClassicIdenticon) WithMinContrast(ratio float64) *ClassicIdenticon {
+	identicon.minContrast = ratio
+	return identicon
+}
+
 // mapValue maps a value from one range to another
 func mapValue(value uint32, vmin, vmax, dmin, dmax uint32) float32 {
 	if vmax == vmin {
@@ -60,138 +119,20 @@ func (identicon *ClassicIdenticon) getByte(n int) byte {
 	return identicon.source[n%len(identicon.source)]
 }
 
-// foreground computes primary color
+// foreground computes primary color via the active palette
 func (identicon *ClassicIdenticon) foreground() color.Color {
 	if len(identicon.source) < 32 {
 		return color.RGBA{0, 0, 0, 255}
 	}
-
-	// Use bit 255 to decide: 0 → original HSL, 1 → palette
-	if !identicon.getBit(255) {
-		// Original HSL algorithm — soft and harmonious
-		h1 := (uint16(identicon.getByte(28)) & 0x0f) << 8
-		h2 := uint16(identicon.getByte(29))
-		h := uint32(h1 | h2)
-		s := uint32(identicon.getByte(30))
-		l := uint32(identicon.getByte(31))
-
-		hue := mapValue(h, 0, 4095, 0, 360)
-		sat := mapValue(s, 0, 255, 0, 20)
-		lum := mapValue(l, 0, 255, 0, 20)
-
-		return identicon.hslToRgb(hue, 65.0-sat, 75.0-lum)
-	}
-
-	// Vibrant color palette — 16 beautiful, distinct colors
-	palette := []color.RGBA{
-		{0x00, 0xbf, 0x93, 0xff}, // turquoise
-		{0x2d, 0xcc, 0x70, 0xff}, // mint
-		{0x42, 0xe4, 0x53, 0xff}, // green
-		{0xf1, 0xc4, 0x0f, 0xff}, // yellowOrange
-		{0xe6, 0x7f, 0x22, 0xff}, // brown
-		{0xff, 0x94, 0x4e, 0xff}, // orange
-		{0xe8, 0x4c, 0x3d, 0xff}, // red
-		{0x35, 0x98, 0xdb, 0xff}, // blue
-		{0x9a, 0x59, 0xb5, 0xff}, // purple
-		{0xef, 0x3e, 0x96, 0xff}, // magenta
-		{0xdf, 0x21, 0xb9, 0xff}, // violet
-		{0x7d, 0xc2, 0xd2, 0xff}, // lightBlue
-		{0x16, 0xa0, 0x86, 0xff}, // turquoiseIntense
-		{0x27, 0xae, 0x61, 0xff}, // mintIntense
-		{0x24, 0xc3, 0x33, 0xff}, // greenIntense
-		{0x1c, 0xab, 0xbb, 0xff}, // lightBlueIntense
-	}
-
-	// Use bits 248-251 to select color (4 bits → 16 colors)
-	colorIndex := 0
-	for i := 0; i < 4; i++ {
-		if identicon.getBit(248 + i) {
-			colorIndex |= 1 << i
-		}
-	}
-	return palette[colorIndex%len(palette)]
+	return identicon.palette.Foreground(identicon)
 }
 
-// secondaryColor computes second color (for 2-color mode)
+// secondaryColor computes second color (for 2-color mode) via the active palette
 func (identicon *ClassicIdenticon) secondaryColor() color.Color {
 	if len(identicon.source) < 32 {
 		return color.RGBA{100, 100, 100, 255}
 	}
-
-	// Use different bits: 244-247 for second color
-	colorIndex := 0
-	for i := 0; i < 4; i++ {
-		if identicon.getBit(244 + i) {
-			colorIndex |= 1 << i
-		}
-	}
-
-	palette := []color.RGBA{
-		{0x34, 0x49, 0x5e, 0xff}, // darkBlue
-		{0x95, 0xa5, 0xa5, 0xff}, // grey
-		{0xd2, 0x54, 0x00, 0xff}, // brownIntense
-		{0xc1, 0x39, 0x2b, 0xff}, // redIntense
-		{0x29, 0x7f, 0xb8, 0xff}, // blueIntense
-		{0x8d, 0x44, 0xad, 0xff}, // purpleIntense
-		{0xbe, 0x12, 0x7e, 0xff}, // violetIntense
-		{0xe5, 0x23, 0x83, 0xff}, // magentaIntense
-		{0x27, 0xae, 0x61, 0xff}, // mintIntense
-		{0x24, 0xc3, 0x33, 0xff}, // greenIntense
-		{0xd9, 0xd9, 0x21, 0xff}, // yellowIntense
-		{0xf3, 0x9c, 0x11, 0xff}, // yellowOrangeIntense
-		{0xff, 0x55, 0x00, 0xff}, // orangeIntense
-		{0x1c, 0xab, 0xbb, 0xff}, // lightBlueIntense
-		{0x23, 0x23, 0x23, 0xff}, // lightBlackIntense
-		{0x7e, 0x8c, 0x8d, 0xff}, // greyIntense
-	}
-
-	return palette[colorIndex%len(palette)]
-}
-
-// hslToRgb converts HSL to RGB in original style
-func (identicon *ClassicIdenticon) hslToRgb(h, s, l float32) color.Color {
-	hue := h / 360.0
-	sat := s / 100.0
-	lum := l / 100.0
-
-	var b float32
-	if lum <= 0.5 {
-		b = lum * (sat + 1.0)
-	} else {
-		b = lum + sat - lum*sat
-	}
-	a := lum*2.0 - b
-
-	red := identicon.hueToRgb(a, b, hue+1.0/3.0)
-	green := identicon.hueToRgb(a, b, hue)
-	blue := identicon.hueToRgb(a, b, hue-1.0/3.0)
-
-	return color.RGBA{
-		R: uint8(math.Round(float64(red * 255.0))),
-		G: uint8(math.Round(float64(green * 255.0))),
-		B: uint8(math.Round(float64(blue * 255.0))),
-		A: 255,
-	}
-}
-
-// hueToRgb helper for color conversion
-func (identicon *ClassicIdenticon) hueToRgb(a, b, hue float32) float32 {
-	if hue < 0 {
-		hue += 1.0
-	} else if hue >= 1.0 {
-		hue -= 1.0
-	}
-
-	switch {
-	case hue < 1.0/6.0:
-		return a + (b-a)*6.0*hue
-	case hue < 0.5:
-		return b
-	case hue < 2.0/3.0:
-		return a + (b-a)*(2.0/3.0-hue)*6.0
-	default:
-		return a
-	}
+	return identicon.palette.Secondary(identicon)
 }
 
 // drawRect draws a solid rectangle
@@ -226,86 +167,50 @@ func (identicon *ClassicIdenticon) drawRect(img *image.RGBA, x0, y0, x1, y1 int,
 	}
 }
 
-// generatePixelPattern generates 5x5 symmetric pixel grid — using individual bits
-// Returns two layers: primary and secondary
+// generatePixelPattern generates the n x n symmetric pixel grid (n set via
+// WithGrid, mirrored/rotated per WithSymmetry) from individual source bits.
+// Returns two layers: primary and secondary.
 func (identicon *ClassicIdenticon) generatePixelPattern() ([]bool, []bool) {
-	primary := make([]bool, 25)
-	secondary := make([]bool, 25)
-
-	// Use bits 0-14 for primary pattern (15 bits)
-	bitIndex := 0
-	for row := 0; row < 5; row++ {
-		for col := 0; col < 3; col++ {
-			paint := identicon.getBit(bitIndex)
-			bitIndex++
-
-			ix := row*5 + col
-			mirrorIx := row*5 + (4 - col)
-			primary[ix] = paint
-			primary[mirrorIx] = paint
-		}
-	}
+	n := identicon.grid
+	half := (n + 1) / 2
+	perLayer := requiredPatternBits(n)
 
-	// Use bits 15-29 for secondary pattern (next 15 bits)
-	for row := 0; row < 5; row++ {
-		for col := 0; col < 3; col++ {
-			paint := identicon.getBit(bitIndex)
-			bitIndex++
+	identicon.ensureSourceBits(2 * perLayer)
 
-			ix := row*5 + col
-			mirrorIx := row*5 + (4 - col)
-			secondary[ix] = paint
-			secondary[mirrorIx] = paint
-		}
-	}
+	primary := identicon.layerBits(n, half, 0)
+	secondary := identicon.layerBits(n, half, perLayer)
 
 	return primary, secondary
 }
 
 // Generate creates the identicon for UI display (respects theme)
 func (identicon *ClassicIdenticon) Generate() image.Image {
-	const (
-		pixelSize  = 36
-		spriteSize = 5
-		margin     = (256 - pixelSize*spriteSize) / 2
-	)
+	spriteSize := identicon.grid
+	pixelSize := identicon.size / spriteSize
+	margin := (identicon.size - pixelSize*spriteSize) / 2
 
-	primaryColor := identicon.foreground()
-	secondaryColor := identicon.secondaryColor()
 	img := image.NewRGBA(image.Rect(0, 0, identicon.size, identicon.size))
 
-	// Background adapts to theme — use bits 252-254 to pick variation
-	bgChoice := 0
-	for i := 0; i < 3; i++ {
-		if identicon.getBit(252 + i) {
-			bgChoice |= 1 << i
-		}
+	variant := ThemeVariantLight
+	if fyne.CurrentApp().Settings().ThemeVariant() == theme.VariantDark {
+		variant = ThemeVariantDark
 	}
-	bgChoice %= 3
+	bg := identicon.palette.Background(variant, identicon)
 
-	lightBackgrounds := []color.RGBA{
-		{255, 255, 255, 255}, // pure white
-		{243, 245, 247, 255}, // light1
-		{236, 240, 241, 255}, // light2
-	}
-	darkBackgrounds := []color.RGBA{
-		{30, 30, 30, 255},    // dark gray
-		{45, 62, 80, 255},     // darkBlueIntense
-		{57, 57, 57, 255},     // dark2
-	}
+	primaryColor := ensureContrast(identicon.foreground(), bg, identicon.minContrast)
+	secondaryColor := ensureContrast(identicon.secondaryColor(), bg, identicon.minContrast)
 
-	var bg color.RGBA
-	if fyne.CurrentApp().Settings().ThemeVariant() == theme.VariantDark {
-		bg = darkBackgrounds[bgChoice]
-	} else {
-		bg = lightBackgrounds[bgChoice]
+	rgba := toRGBA(bg)
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = rgba.R
+		img.Pix[i+1] = rgba.G
+		img.Pix[i+2] = rgba.B
+		img.Pix[i+3] = rgba.A
 	}
 
-	for i := 0; i < len(img.Pix); i += 4 {
-		img.Pix[i] = bg.R
-		img.Pix[i+1] = bg.G
-		img.Pix[i+2] = bg.B
-		img.Pix[i+3] = bg.A
+	if identicon.mode == ModeBlocks {
+		identicon.drawBlocks(img, primaryColor)
+		return img
 	}
 
 	primaryPixels, secondaryPixels := identicon.generatePixelPattern()
@@ -335,45 +240,42 @@ func (identicon *ClassicIdenticon) Generate() image.Image {
 	return img
 }
 
+// exportBackground picks the fixed (non theme-adaptive) background color
+// used when saving an identicon, or fully transparent when requested.
+func (identicon *ClassicIdenticon) exportBackground(transparent bool) color.RGBA {
+	if transparent {
+		return color.RGBA{0, 0, 0, 0}
+	}
+	return toRGBA(identicon.palette.Background(ThemeVariantLight, identicon))
+}
+
 // GenerateForExport generates identicon with fixed background for saving
 func (identicon *ClassicIdenticon) GenerateForExport(transparent bool) image.Image {
-	const (
-		pixelSize  = 36
-		spriteSize = 5
-		margin     = (256 - pixelSize*spriteSize) / 2
-	)
+	spriteSize := identicon.grid
+	pixelSize := identicon.size / spriteSize
+	margin := (identicon.size - pixelSize*spriteSize) / 2
+
+	bgRGBA := identicon.exportBackground(transparent)
 
 	primaryColor := identicon.foreground()
 	secondaryColor := identicon.secondaryColor()
-	img := image.NewRGBA(image.Rect(0, 0, identicon.size, identicon.size))
+	if !transparent {
+		primaryColor = ensureContrast(primaryColor, bgRGBA, identicon.minContrast)
+		secondaryColor = ensureContrast(secondaryColor, bgRGBA, identicon.minContrast)
+	}
 
-	// Set export background
-	var bg color.RGBA
-	if transparent {
-		bg = color.RGBA{0, 0, 0, 0} // fully transparent
-	} else {
-		// Use bits 252-254 for background choice
-		bgChoice := 0
-		for i := 0; i < 3; i++ {
-			if identicon.getBit(252 + i) {
-				bgChoice |= 1 << i
-			}
-		}
-		bgChoice %= 3
+	img := image.NewRGBA(image.Rect(0, 0, identicon.size, identicon.size))
 
-		lightBackgrounds := []color.RGBA{
-			{255, 255, 255, 255},
-			{243, 245, 247, 255},
-			{236, 240, 241, 255},
-		}
-		bg = lightBackgrounds[bgChoice]
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = bgRGBA.R
+		img.Pix[i+1] = bgRGBA.G
+		img.Pix[i+2] = bgRGBA.B
+		img.Pix[i+3] = bgRGBA.A
 	}
 
-	for i := 0; i < len(img.Pix); i += 4 {
-		img.Pix[i] = bg.R
-		img.Pix[i+1] = bg.G
-		img.Pix[i+2] = bg.B
-		img.Pix[i+3] = bg.A
+	if identicon.mode == ModeBlocks {
+		identicon.drawBlocks(img, primaryColor)
+		return img
 	}
 
 	primaryPixels, secondaryPixels := identicon.generatePixelPattern()
@@ -419,6 +321,16 @@ func max(a, b int) int {
 }
 
 func main() {
+	serveAddr := flag.String("serve", "", "serve identicons as an HTTP avatar service on this address (e.g. :8080) instead of launching the GUI")
+	flag.Parse()
+
+	if *serveAddr != "" {
+		if err := runAvatarServer(*serveAddr); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	myApp := app.New()
 	myWindow := myApp.NewWindow("Identicons")
 	myWindow.Resize(fyne.NewSize(480, 480))
@@ -429,6 +341,71 @@ func main() {
 	input := widget.NewEntry()
 	input.SetPlaceHolder("Enter text...")
 
+	styleSelect := widget.NewSelect([]string{"Pixel", "Blocks"}, nil)
+	styleSelect.SetSelected("Pixel")
+
+	selectedMode := func() RenderMode {
+		if styleSelect.Selected == "Blocks" {
+			return ModeBlocks
+		}
+		return ModePixel
+	}
+
+	gridSelect := widget.NewSelect([]string{"5x5", "6x6", "7x7", "8x8"}, nil)
+	gridSelect.SetSelected("5x5")
+
+	selectedGrid := func() int {
+		switch gridSelect.Selected {
+		case "6x6":
+			return 6
+		case "7x7":
+			return 7
+		case "8x8":
+			return 8
+		default:
+			return 5
+		}
+	}
+
+	symmetrySelect := widget.NewSelect([]string{"Horizontal", "Vertical", "Both", "Rotational"}, nil)
+	symmetrySelect.SetSelected("Horizontal")
+
+	selectedSymmetry := func() SymmetryMode {
+		switch symmetrySelect.Selected {
+		case "Vertical":
+			return SymmetryVertical
+		case "Both":
+			return SymmetryBoth
+		case "Rotational":
+			return SymmetryRotational
+		default:
+			return SymmetryHorizontal
+		}
+	}
+
+	sizeSlider := widget.NewSlider(64, 512)
+	sizeSlider.SetValue(256)
+
+	paletteSelect := widget.NewSelect([]string{"Classic", "Vibrant", "HSL", "Pastel", "Monochrome", "Solarized"}, nil)
+	paletteSelect.SetSelected("Classic")
+
+	selectedPalette := func() Palette {
+		switch paletteSelect.Selected {
+		case "Vibrant":
+			return vibrantPalette{}
+		case "HSL":
+			return hslPalette{}
+		case "Pastel":
+			return pastelPalette{}
+		case "Monochrome":
+			return monochromePalette{}
+		case "Solarized":
+			return solarizedPalette{}
+		default:
+			return classicPalette{}
+		}
+	}
+
 	generateBtn := widget.NewButton("Generate Identicon", func() {
 		if input.Text == "" {
 			dialog.ShowInformation("Error", "Please enter some text.", myWindow)
@@ -436,9 +413,19 @@ func main() {
 		}
 
 		hash := sha256.Sum256([]byte(input.Text))
+		mode := selectedMode()
+		grid := selectedGrid()
+		symmetry := selectedSymmetry()
+		size := int(sizeSlider.Value)
+		palette := selectedPalette()
 
 		// Generate for UI (theme-adaptive background)
-		identiconDisplay := NewClassicIdenticon(hash[:])
+		identiconDisplay := NewClassicIdenticon(hash[:]).
+			WithMode(mode).
+			WithGrid(grid).
+			WithSymmetry(symmetry).
+			WithSize(size).
+			WithPalette(palette)
 		imgDisplay := identiconDisplay.Generate()
 
 		fyneImg := canvas.NewImageFromImage(imgDisplay)
@@ -464,11 +451,26 @@ func main() {
 			layout.NewSpacer(),
 		)
 
+		formatSelect := widget.NewSelect([]string{"PNG", "SVG", "JPEG", "GIF", "BMP"}, nil)
+		formatSelect.SetSelected("PNG")
+
+		formatToggleContainer := container.NewHBox(
+			formatSelect,
+			layout.NewSpacer(),
+		)
+
 		// Save button
-		saveBtn := widget.NewButton("Save as PNG", func() {
+		saveBtn := widget.NewButton("Save", func() {
 			hashForSave := sha256.Sum256([]byte(input.Text))
-			identiconForSave := NewClassicIdenticon(hashForSave[:])
-			imgToSave := identiconForSave.GenerateForExport(transparentCheck.Checked)
+			identiconForSave := NewClassicIdenticon(hashForSave[:]).
+				WithMode(mode).
+				WithGrid(grid).
+				WithSymmetry(symmetry).
+				WithSize(size).
+				WithPalette(palette)
+
+			format := formatSelect.Selected
+			ext := strings.ToLower(format)
 
 			fileDialog := dialog.NewFileSave(
 				func(uc fyne.URIWriteCloser, err error) {
@@ -477,11 +479,15 @@ func main() {
 					}
 					defer uc.Close()
 
-					err = png.Encode(uc, imgToSave)
+					data, err := encodeIdenticon(identiconForSave, format, transparentCheck.Checked)
 					if err != nil {
 						dialog.ShowError(err, myWindow)
 						return
 					}
+					if _, err := uc.Write(data); err != nil {
+						dialog.ShowError(err, myWindow)
+						return
+					}
 
 					bgMsg := "white background"
 					if transparentCheck.Checked {
@@ -491,7 +497,7 @@ func main() {
 				},
 				myWindow,
 			)
-			fileDialog.SetFileName("identicon.png")
+			fileDialog.SetFileName("identicon." + ext)
 			fileDialog.Show()
 		})
 
@@ -513,6 +519,12 @@ func main() {
 				transparentToggleContainer,
 				layout.NewSpacer(),
 			),
+
+			container.NewHBox(
+				layout.NewSpacer(),
+				formatToggleContainer,
+				layout.NewSpacer(),
+			),
 		)
 
 		dialog.ShowCustom("", "OK", content, myWindow)
@@ -531,20 +543,35 @@ func main() {
 	})
 	themeSwitch.Importance = widget.LowImportance
 
+	contactSheetBtn := widget.NewButton("Contact Sheet", func() {
+		showContactSheetWindow(myApp)
+	})
+	contactSheetBtn.Importance = widget.LowImportance
+
 	// Create top-right aligned layout — with your exact style
 	topBar := container.NewHBox(
 		layout.NewSpacer(), // pushes toggle to the right
+		contactSheetBtn,
 		themeSwitch,
 	)
 
 	content := container.NewBorder(
-		topBar,           // top: theme switch right-aligned
-		nil,              // bottom: nothing
-		nil,              // left: nothing
-		nil,              // right: nothing
+		topBar, // top: theme switch right-aligned
+		nil,    // bottom: nothing
+		nil,    // left: nothing
+		nil,    // right: nothing
 		container.NewVBox( // center: main content
 			layout.NewSpacer(),
 			input,
+			container.NewHBox(
+				layout.NewSpacer(),
+				styleSelect,
+				gridSelect,
+				symmetrySelect,
+				paletteSelect,
+				layout.NewSpacer(),
+			),
+			sizeSlider,
 			layout.NewSpacer(),
 			container.NewHBox(
 				layout.NewSpacer(),
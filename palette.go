@@ -0,0 +1,424 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+// ThemeVariant selects which background set a Palette should draw from.
+type ThemeVariant int
+
+const (
+	ThemeVariantLight ThemeVariant = iota
+	ThemeVariantDark
+)
+
+// Bits is the subset of ClassicIdenticon a Palette needs to derive
+// deterministic colors from the source hash. *ClassicIdenticon satisfies
+// this via its existing getBit/getByte helpers.
+type Bits interface {
+	getBit(n int) bool
+	getByte(n int) byte
+}
+
+// Palette picks the primary, secondary, and background colors for an
+// identicon from its source bits.
+type Palette interface {
+	Foreground(bits Bits) color.Color
+	Secondary(bits Bits) color.Color
+	Background(variant ThemeVariant, bits Bits) color.Color
+}
+
+// colorBits reads n bits starting at offset into a little-endian index,
+// the same scheme foreground()/secondaryColor() used before palettes were
+// made pluggable.
+func colorBits(bits Bits, offset, n int) int {
+	index := 0
+	for i := 0; i < n; i++ {
+		if bits.getBit(offset + i) {
+			index |= 1 << i
+		}
+	}
+	return index
+}
+
+var lightBackgrounds = []color.RGBA{
+	{255, 255, 255, 255}, // pure white
+	{243, 245, 247, 255}, // light1
+	{236, 240, 241, 255}, // light2
+}
+
+var darkBackgrounds = []color.RGBA{
+	{30, 30, 30, 255}, // dark gray
+	{45, 62, 80, 255}, // darkBlueIntense
+	{57, 57, 57, 255}, // dark2
+}
+
+// backgroundChoice picks one of the 3 fixed light/dark backgrounds using
+// bits 252-254, the scheme every built-in palette shares.
+func backgroundChoice(variant ThemeVariant, bits Bits) color.Color {
+	choice := colorBits(bits, 252, 3) % 3
+	if variant == ThemeVariantDark {
+		return darkBackgrounds[choice]
+	}
+	return lightBackgrounds[choice]
+}
+
+// vibrantPalette is the original "vibrant" 16-color foreground/secondary
+// set, selected via bits 248-251 and 244-247.
+type vibrantPalette struct{}
+
+var vibrantColors = []color.RGBA{
+	{0x00, 0xbf, 0x93, 0xff}, // turquoise
+	{0x2d, 0xcc, 0x70, 0xff}, // mint
+	{0x42, 0xe4, 0x53, 0xff}, // green
+	{0xf1, 0xc4, 0x0f, 0xff}, // yellowOrange
+	{0xe6, 0x7f, 0x22, 0xff}, // brown
+	{0xff, 0x94, 0x4e, 0xff}, // orange
+	{0xe8, 0x4c, 0x3d, 0xff}, // red
+	{0x35, 0x98, 0xdb, 0xff}, // blue
+	{0x9a, 0x59, 0xb5, 0xff}, // purple
+	{0xef, 0x3e, 0x96, 0xff}, // magenta
+	{0xdf, 0x21, 0xb9, 0xff}, // violet
+	{0x7d, 0xc2, 0xd2, 0xff}, // lightBlue
+	{0x16, 0xa0, 0x86, 0xff}, // turquoiseIntense
+	{0x27, 0xae, 0x61, 0xff}, // mintIntense
+	{0x24, 0xc3, 0x33, 0xff}, // greenIntense
+	{0x1c, 0xab, 0xbb, 0xff}, // lightBlueIntense
+}
+
+var secondaryColors = []color.RGBA{
+	{0x34, 0x49, 0x5e, 0xff}, // darkBlue
+	{0x95, 0xa5, 0xa5, 0xff}, // grey
+	{0xd2, 0x54, 0x00, 0xff}, // brownIntense
+	{0xc1, 0x39, 0x2b, 0xff}, // redIntense
+	{0x29, 0x7f, 0xb8, 0xff}, // blueIntense
+	{0x8d, 0x44, 0xad, 0xff}, // purpleIntense
+	{0xbe, 0x12, 0x7e, 0xff}, // violetIntense
+	{0xe5, 0x23, 0x83, 0xff}, // magentaIntense
+	{0x27, 0xae, 0x61, 0xff}, // mintIntense
+	{0x24, 0xc3, 0x33, 0xff}, // greenIntense
+	{0xd9, 0xd9, 0x21, 0xff}, // yellowIntense
+	{0xf3, 0x9c, 0x11, 0xff}, // yellowOrangeIntense
+	{0xff, 0x55, 0x00, 0xff}, // orangeIntense
+	{0x1c, 0xab, 0xbb, 0xff}, // lightBlueIntense
+	{0x23, 0x23, 0x23, 0xff}, // lightBlackIntense
+	{0x7e, 0x8c, 0x8d, 0xff}, // greyIntense
+}
+
+func (vibrantPalette) Foreground(bits Bits) color.Color {
+	return vibrantColors[colorBits(bits, 248, 4)%len(vibrantColors)]
+}
+
+func (vibrantPalette) Secondary(bits Bits) color.Color {
+	return secondaryColors[colorBits(bits, 244, 4)%len(secondaryColors)]
+}
+
+func (vibrantPalette) Background(variant ThemeVariant, bits Bits) color.Color {
+	return backgroundChoice(variant, bits)
+}
+
+// hslPalette is the original soft, harmonious HSL-generated foreground.
+type hslPalette struct{}
+
+func (hslPalette) Foreground(bits Bits) color.Color {
+	h1 := (uint16(bits.getByte(28)) & 0x0f) << 8
+	h2 := uint16(bits.getByte(29))
+	h := uint32(h1 | h2)
+	s := uint32(bits.getByte(30))
+	l := uint32(bits.getByte(31))
+
+	hue := mapValue(h, 0, 4095, 0, 360)
+	sat := mapValue(s, 0, 255, 0, 20)
+	lum := mapValue(l, 0, 255, 0, 20)
+
+	return hslToRgb(hue, 65.0-sat, 75.0-lum)
+}
+
+func (hslPalette) Secondary(bits Bits) color.Color {
+	return secondaryColors[colorBits(bits, 244, 4)%len(secondaryColors)]
+}
+
+func (hslPalette) Background(variant ThemeVariant, bits Bits) color.Color {
+	return backgroundChoice(variant, bits)
+}
+
+// classicPalette is the default: bit 255 switches between the HSL
+// generator and the vibrant palette, matching this module's original,
+// pre-Palette behavior exactly.
+type classicPalette struct{}
+
+func (classicPalette) Foreground(bits Bits) color.Color {
+	if !bits.getBit(255) {
+		return hslPalette{}.Foreground(bits)
+	}
+	return vibrantPalette{}.Foreground(bits)
+}
+
+func (classicPalette) Secondary(bits Bits) color.Color {
+	return vibrantPalette{}.Secondary(bits)
+}
+
+func (classicPalette) Background(variant ThemeVariant, bits Bits) color.Color {
+	return backgroundChoice(variant, bits)
+}
+
+// pastelPalette is a muted, low-saturation set.
+type pastelPalette struct{}
+
+var pastelColors = []color.RGBA{
+	{0xa8, 0xd8, 0xc9, 0xff},
+	{0xb8, 0xe0, 0xb0, 0xff},
+	{0xf0, 0xe0, 0xa0, 0xff},
+	{0xf0, 0xc8, 0xa0, 0xff},
+	{0xe8, 0xb0, 0xa8, 0xff},
+	{0xd8, 0xb0, 0xd0, 0xff},
+	{0xb0, 0xc0, 0xe8, 0xff},
+	{0xa0, 0xd0, 0xe0, 0xff},
+	{0xc0, 0xd8, 0xa8, 0xff},
+	{0xe0, 0xd0, 0xb8, 0xff},
+	{0xd0, 0xc0, 0xe0, 0xff},
+	{0xa8, 0xc8, 0xc0, 0xff},
+	{0xe8, 0xc8, 0xc8, 0xff},
+	{0xc8, 0xd8, 0xd8, 0xff},
+	{0xd8, 0xd0, 0xa0, 0xff},
+	{0xb8, 0xc8, 0xd8, 0xff},
+}
+
+var pastelSecondaryColors = []color.RGBA{
+	{0x88, 0x98, 0x98, 0xff},
+	{0x98, 0xa8, 0x90, 0xff},
+	{0xc8, 0xb8, 0x80, 0xff},
+	{0xc8, 0xa0, 0x80, 0xff},
+	{0xc0, 0x90, 0x88, 0xff},
+	{0xb0, 0x90, 0xb0, 0xff},
+	{0x90, 0xa0, 0xc8, 0xff},
+	{0x80, 0xb0, 0xc0, 0xff},
+	{0xa0, 0xb8, 0x88, 0xff},
+	{0xc0, 0xb0, 0x98, 0xff},
+	{0xb0, 0xa0, 0xc0, 0xff},
+	{0x88, 0xa8, 0xa0, 0xff},
+	{0xc8, 0xa8, 0xa8, 0xff},
+	{0xa8, 0xb8, 0xb8, 0xff},
+	{0xb8, 0xb0, 0x80, 0xff},
+	{0x98, 0xa8, 0xb8, 0xff},
+}
+
+func (pastelPalette) Foreground(bits Bits) color.Color {
+	return pastelColors[colorBits(bits, 248, 4)%len(pastelColors)]
+}
+
+func (pastelPalette) Secondary(bits Bits) color.Color {
+	return pastelSecondaryColors[colorBits(bits, 244, 4)%len(pastelSecondaryColors)]
+}
+
+func (pastelPalette) Background(variant ThemeVariant, bits Bits) color.Color {
+	return backgroundChoice(variant, bits)
+}
+
+// monochromePalette maps the source bits to shades of gray only.
+type monochromePalette struct{}
+
+func (monochromePalette) Foreground(bits Bits) color.Color {
+	shade := uint8(mapValue(uint32(colorBits(bits, 248, 4)), 0, 15, 20, 80))
+	return color.RGBA{shade, shade, shade, 255}
+}
+
+func (monochromePalette) Secondary(bits Bits) color.Color {
+	shade := uint8(mapValue(uint32(colorBits(bits, 244, 4)), 0, 15, 120, 200))
+	return color.RGBA{shade, shade, shade, 255}
+}
+
+func (monochromePalette) Background(variant ThemeVariant, bits Bits) color.Color {
+	return backgroundChoice(variant, bits)
+}
+
+// solarizedPalette uses the 16 standard Solarized accent/base colors.
+type solarizedPalette struct{}
+
+var solarizedColors = []color.RGBA{
+	{0xb5, 0x89, 0x00, 0xff}, // yellow
+	{0xcb, 0x4b, 0x16, 0xff}, // orange
+	{0xdc, 0x32, 0x2f, 0xff}, // red
+	{0xd3, 0x36, 0x82, 0xff}, // magenta
+	{0x6c, 0x71, 0xc4, 0xff}, // violet
+	{0x26, 0x8b, 0xd2, 0xff}, // blue
+	{0x2a, 0xa1, 0x98, 0xff}, // cyan
+	{0x85, 0x99, 0x00, 0xff}, // green
+	{0x00, 0x2b, 0x36, 0xff}, // base03
+	{0x07, 0x36, 0x42, 0xff}, // base02
+	{0x58, 0x6e, 0x75, 0xff}, // base01
+	{0x65, 0x7b, 0x83, 0xff}, // base00
+	{0x83, 0x94, 0x96, 0xff}, // base0
+	{0x93, 0xa1, 0xa1, 0xff}, // base1
+	{0xee, 0xe8, 0xd5, 0xff}, // base2
+	{0xfd, 0xf6, 0xe3, 0xff}, // base3
+}
+
+func (solarizedPalette) Foreground(bits Bits) color.Color {
+	return solarizedColors[colorBits(bits, 248, 4)%len(solarizedColors)]
+}
+
+func (solarizedPalette) Secondary(bits Bits) color.Color {
+	return solarizedColors[(colorBits(bits, 244, 4)+8)%len(solarizedColors)]
+}
+
+func (solarizedPalette) Background(variant ThemeVariant, bits Bits) color.Color {
+	if variant == ThemeVariantDark {
+		return solarizedColors[8] // base03
+	}
+	return solarizedColors[15] // base3
+}
+
+// hslToRgb converts HSL (h in [0,360), s and l in [0,100]) to RGB, in the
+// same style as the original per-identicon implementation.
+func hslToRgb(h, s, l float32) color.Color {
+	hue := h / 360.0
+	sat := s / 100.0
+	lum := l / 100.0
+
+	var b float32
+	if lum <= 0.5 {
+		b = lum * (sat + 1.0)
+	} else {
+		b = lum + sat - lum*sat
+	}
+	a := lum*2.0 - b
+
+	return color.RGBA{
+		R: uint8(math.Round(float64(hueToRgb(a, b, hue+1.0/3.0) * 255.0))),
+		G: uint8(math.Round(float64(hueToRgb(a, b, hue) * 255.0))),
+		B: uint8(math.Round(float64(hueToRgb(a, b, hue-1.0/3.0) * 255.0))),
+		A: 255,
+	}
+}
+
+func hueToRgb(a, b, hue float32) float32 {
+	if hue < 0 {
+		hue += 1.0
+	} else if hue >= 1.0 {
+		hue -= 1.0
+	}
+
+	switch {
+	case hue < 1.0/6.0:
+		return a + (b-a)*6.0*hue
+	case hue < 0.5:
+		return b
+	case hue < 2.0/3.0:
+		return a + (b-a)*(2.0/3.0-hue)*6.0
+	default:
+		return a
+	}
+}
+
+// rgbToHSL is the inverse of hslToRgb, needed by the contrast-safety pass
+// to nudge a color's lightness while keeping its hue and saturation.
+func rgbToHSL(c color.Color) (h, s, l float32) {
+	r, g, b, _ := c.RGBA()
+	rf := float32(r>>8) / 255.0
+	gf := float32(g>>8) / 255.0
+	bf := float32(b>>8) / 255.0
+
+	max := math.Max(float64(rf), math.Max(float64(gf), float64(bf)))
+	min := math.Min(float64(rf), math.Min(float64(gf), float64(bf)))
+	l = float32((max + min) / 2)
+
+	if max == min {
+		return 0, 0, l * 100
+	}
+
+	d := max - min
+	if l > 0.5 {
+		s = float32(d / (2 - max - min))
+	} else {
+		s = float32(d / (max + min))
+	}
+
+	switch max {
+	case float64(rf):
+		h = (gf - bf) / float32(d)
+		if gf < bf {
+			h += 6
+		}
+	case float64(gf):
+		h = (bf-rf)/float32(d) + 2
+	default:
+		h = (rf-gf)/float32(d) + 4
+	}
+	h *= 60
+
+	return h, s * 100, l * 100
+}
+
+// toRGBA converts any color.Color to color.RGBA with straight (non-premultiplied)
+// 8-bit channels, for direct writes into an image.RGBA's Pix buffer.
+func toRGBA(c color.Color) color.RGBA {
+	r, g, b, a := c.RGBA()
+	return color.RGBA{
+		R: uint8(r >> 8),
+		G: uint8(g >> 8),
+		B: uint8(b >> 8),
+		A: uint8(a >> 8),
+	}
+}
+
+// relativeLuminance computes the WCAG relative luminance of c.
+func relativeLuminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	channel := func(v uint32) float64 {
+		c := float64(v>>8) / 255.0
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+}
+
+// contrastRatio computes the WCAG contrast ratio between two colors (always
+// >= 1, where 1 means identical luminance).
+func contrastRatio(a, b color.Color) float64 {
+	la := relativeLuminance(a) + 0.05
+	lb := relativeLuminance(b) + 0.05
+	if la < lb {
+		la, lb = lb, la
+	}
+	return la / lb
+}
+
+// ensureContrast nudges fg's lightness toward the pole opposite bg until it
+// meets minContrast against bg, or the lightness bound is reached.
+func ensureContrast(fg, bg color.Color, minContrast float64) color.Color {
+	if contrastRatio(fg, bg) >= minContrast {
+		return fg
+	}
+
+	h, s, l := rgbToHSL(fg)
+	darken := relativeLuminance(bg) > 0.5
+
+	const step = 2.0
+	for i := 0; i < 50; i++ {
+		if darken {
+			l -= step
+			if l < 0 {
+				l = 0
+			}
+		} else {
+			l += step
+			if l > 100 {
+				l = 100
+			}
+		}
+
+		candidate := hslToRgb(h, s, l)
+		if contrastRatio(candidate, bg) >= minContrast {
+			return candidate
+		}
+		if l <= 0 || l >= 100 {
+			return candidate
+		}
+	}
+
+	return hslToRgb(h, s, l)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// avatarCacheCapacity bounds how many encoded avatars the in-memory LRU
+// cache keeps before evicting the least recently used entry.
+const avatarCacheCapacity = 512
+
+// maxAvatarSize bounds the ?size= query param so a single request can't
+// force a multi-gigabyte RGBA allocation.
+const maxAvatarSize = 1024
+
+// cacheKey identifies one rendered avatar variant.
+type cacheKey struct {
+	hash        [32]byte
+	size        int
+	mode        RenderMode
+	transparent bool
+	format      string
+}
+
+// lruCache is a small, self-contained least-recently-used cache, the same
+// style as the rest of this module's from-scratch helpers (no external
+// cache dependency).
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[cacheKey]*list.Element
+}
+
+type cacheEntry struct {
+	key  cacheKey
+	data []byte
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[cacheKey]*list.Element),
+	}
+}
+
+func (c *lruCache) get(key cacheKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).data, true
+}
+
+func (c *lruCache) put(key cacheKey, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*cacheEntry).data = data
+		return
+	}
+
+	el := c.ll.PushFront(&cacheEntry{key: key, data: data})
+	c.items[key] = el
+
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// runAvatarServer starts the HTTP avatar service on addr, serving
+// GET /avatar/{name}.{png|svg}?size=NNN&mode=pixel|blocks&transparent=1.
+func runAvatarServer(addr string) error {
+	cache := newLRUCache(avatarCacheCapacity)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/avatar/", avatarHandler(cache))
+
+	log.Printf("identicons: avatar server listening on %s", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func avatarHandler(cache *lruCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, format, ok := parseAvatarPath(strings.TrimPrefix(r.URL.Path, "/avatar/"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+
+		size := 256
+		if v, err := strconv.Atoi(query.Get("size")); err == nil && v > 0 {
+			size = min(v, maxAvatarSize)
+		}
+
+		mode := ModePixel
+		if query.Get("mode") == "blocks" {
+			mode = ModeBlocks
+		}
+
+		transparent := query.Get("transparent") == "1"
+
+		hash := sha256.Sum256([]byte(name))
+		key := cacheKey{hash, size, mode, transparent, format}
+		etag := avatarETag(key)
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		data, ok := cache.get(key)
+		if !ok {
+			identicon := NewClassicIdenticon(hash[:]).WithMode(mode).WithSize(size)
+			var err error
+			data, err = encodeIdenticon(identicon, format, transparent)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			cache.put(key, data)
+		}
+
+		w.Header().Set("Content-Type", avatarContentType(format))
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.Header().Set("ETag", etag)
+		w.Write(data)
+	}
+}
+
+// parseAvatarPath splits "name.ext" into name and an encodeIdenticon format
+// ("PNG" or "SVG"). Returns ok=false for unsupported extensions.
+func parseAvatarPath(path string) (name, format string, ok bool) {
+	dot := strings.LastIndexByte(path, '.')
+	if dot < 0 || dot == 0 || dot == len(path)-1 {
+		return "", "", false
+	}
+	name = path[:dot]
+	switch strings.ToLower(path[dot+1:]) {
+	case "png":
+		return name, "PNG", true
+	case "svg":
+		return name, "SVG", true
+	default:
+		return "", "", false
+	}
+}
+
+func avatarContentType(format string) string {
+	if format == "SVG" {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+func avatarETag(key cacheKey) string {
+	return fmt.Sprintf(`"%x-%d-%d-%t-%s"`, key.hash[:8], key.size, key.mode, key.transparent, key.format)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+)
+
+// SymmetryMode selects how generatePixelPattern mirrors or rotates the
+// bits it reads from the source into a full n x n grid.
+type SymmetryMode int
+
+const (
+	// SymmetryHorizontal mirrors each row left-right (the original look).
+	SymmetryHorizontal SymmetryMode = iota
+	// SymmetryVertical mirrors each column top-bottom.
+	SymmetryVertical
+	// SymmetryBoth mirrors both horizontally and vertically (4-way mirror).
+	SymmetryBoth
+	// SymmetryRotational repeats one quadrant with 90/180/270 degree
+	// rotations, giving 4-fold rotational symmetry.
+	SymmetryRotational
+)
+
+const (
+	minGrid     = 5
+	maxGrid     = 8
+	defaultGrid = 5
+)
+
+// clampGrid restricts n to the supported 5x5..8x8 range.
+func clampGrid(n int) int {
+	if n < minGrid {
+		return minGrid
+	}
+	if n > maxGrid {
+		return maxGrid
+	}
+	return n
+}
+
+// requiredPatternBits returns how many source bits generatePixelPattern
+// needs per layer (primary, secondary), following ceil(n*ceil(n/2)).
+func requiredPatternBits(grid int) int {
+	half := (grid + 1) / 2
+	return grid * half
+}
+
+// ensureSourceBits grows identicon.source until it has at least `bits` bits
+// available, re-hashing with SHA-512 (and, if that's still not enough,
+// repeated SHA-256 with an incrementing counter) so the expansion stays
+// deterministic.
+func (identicon *ClassicIdenticon) ensureSourceBits(bits int) {
+	if len(identicon.source)*8 >= bits {
+		return
+	}
+
+	expanded := sha512.Sum512(identicon.source)
+	source := append([]byte{}, expanded[:]...)
+
+	counter := byte(0)
+	for len(source)*8 < bits {
+		next := sha256.Sum256(append(append([]byte{}, identicon.source...), counter))
+		source = append(source, next[:]...)
+		counter++
+	}
+
+	identicon.source = source
+}
+
+// layerBits reads one pattern layer (primary or secondary) starting at
+// startBit, mirroring or rotating according to identicon.symmetry.
+func (identicon *ClassicIdenticon) layerBits(n, half, startBit int) []bool {
+	grid := make([]bool, n*n)
+	bitIndex := startBit
+
+	switch identicon.symmetry {
+	case SymmetryVertical:
+		for col := 0; col < n; col++ {
+			for row := 0; row < half; row++ {
+				paint := identicon.getBit(bitIndex)
+				bitIndex++
+				grid[row*n+col] = paint
+				grid[(n-1-row)*n+col] = paint
+			}
+		}
+
+	case SymmetryBoth:
+		for row := 0; row < half; row++ {
+			for col := 0; col < half; col++ {
+				paint := identicon.getBit(bitIndex)
+				bitIndex++
+				grid[row*n+col] = paint
+				grid[row*n+(n-1-col)] = paint
+				grid[(n-1-row)*n+col] = paint
+				grid[(n-1-row)*n+(n-1-col)] = paint
+			}
+		}
+
+	case SymmetryRotational:
+		for row := 0; row < half; row++ {
+			for col := 0; col < half; col++ {
+				paint := identicon.getBit(bitIndex)
+				bitIndex++
+				grid[row*n+col] = paint             // 0°
+				grid[col*n+(n-1-row)] = paint       // 90°
+				grid[(n-1-row)*n+(n-1-col)] = paint // 180°
+				grid[(n-1-col)*n+row] = paint       // 270°
+			}
+		}
+
+	default: // SymmetryHorizontal
+		for row := 0; row < n; row++ {
+			for col := 0; col < half; col++ {
+				paint := identicon.getBit(bitIndex)
+				bitIndex++
+				grid[row*n+col] = paint
+				grid[row*n+(n-1-col)] = paint
+			}
+		}
+	}
+
+	return grid
+}